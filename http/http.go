@@ -1,41 +1,87 @@
 // Package http provides an HTTP handler for a goqite.Queue.
 // GET receives a message from the queue, if any. If there is no message, it returns a 204 No Content.
-// POST sends a message to the queue.
+//   - `?wait=<duration>` (or a `Prefer: wait=<duration>` header) long-polls instead, blocking until a
+//     message is available or the duration elapses, in which case it returns a 504 Gateway Timeout.
+//     If the client disconnects before that, the request is abandoned with a 408 Request Timeout
+//     (which the client won't see, but which shows up in logs/metrics for that response).
+//   - `?n=<count>` receives up to count messages at once, returned as a JSON array in the
+//     response's `Messages` field (with `Message` still set to the first of them).
+//
+// POST sends a message to the queue, or, given `{"Messages": [...]}` instead of `{"Message": ...}`,
+// sends them all.
 // PUT extends a message's timeout.
 // DELETE deletes a message from the queue.
+//
+// RunnerHandler renders the jobs a jobs.Runner currently has in flight as JSON.
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/maragudk/goqite"
+	"github.com/maragudk/goqite/jobs"
 )
 
 func GoqiteHandler(q *goqite.Queue) http.HandlerFunc {
 	type request struct {
-		Message goqite.Message
+		Message  *goqite.Message
+		Messages []goqite.Message
 	}
 
 	type response struct {
-		Message *goqite.Message
+		Message  *goqite.Message
+		Messages []goqite.Message `json:",omitempty"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			m, err := q.Receive(r.Context())
+			n := 1
+			if nParam := r.URL.Query().Get("n"); nParam != "" {
+				parsed, err := strconv.Atoi(nParam)
+				if err != nil || parsed < 1 {
+					http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+					return
+				}
+				n = parsed
+			}
+
+			wait, err := parseWait(r)
 			if err != nil {
-				http.Error(w, "error receiving message: "+err.Error(), http.StatusInternalServerError)
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
-			if m == nil {
+			ms, err := receiveN(r.Context(), q, n, wait)
+			if err != nil {
+				switch {
+				case errors.Is(err, context.DeadlineExceeded):
+					http.Error(w, "timed out waiting for a message", http.StatusGatewayTimeout)
+				case errors.Is(err, context.Canceled):
+					http.Error(w, "client disconnected while waiting for a message", http.StatusRequestTimeout)
+				default:
+					http.Error(w, "error receiving message: "+err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+
+			if len(ms) == 0 {
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
 
-			if err := json.NewEncoder(w).Encode(response{Message: m}); err != nil {
+			res := response{Message: &ms[0]}
+			if nParam := r.URL.Query().Get("n"); nParam != "" {
+				res.Messages = ms
+			}
+			if err := json.NewEncoder(w).Encode(res); err != nil {
 				http.Error(w, "error encoding message: "+err.Error(), http.StatusInternalServerError)
 				return
 			}
@@ -47,14 +93,23 @@ func GoqiteHandler(q *goqite.Queue) http.HandlerFunc {
 				return
 			}
 
-			if req.Message.Delay < 0 {
-				http.Error(w, "delay cannot be negative", http.StatusBadRequest)
-				return
+			messages := req.Messages
+			if req.Message != nil {
+				messages = append(messages, *req.Message)
 			}
 
-			if err := q.Send(r.Context(), req.Message); err != nil {
-				http.Error(w, "error sending message: "+err.Error(), http.StatusInternalServerError)
-				return
+			for _, m := range messages {
+				if m.Delay < 0 {
+					http.Error(w, "delay cannot be negative", http.StatusBadRequest)
+					return
+				}
+			}
+
+			for _, m := range messages {
+				if err := q.Send(r.Context(), m); err != nil {
+					http.Error(w, "error sending message: "+err.Error(), http.StatusInternalServerError)
+					return
+				}
 			}
 
 		case http.MethodPut:
@@ -64,7 +119,7 @@ func GoqiteHandler(q *goqite.Queue) http.HandlerFunc {
 				return
 			}
 
-			if req.Message.ID == "" {
+			if req.Message == nil || req.Message.ID == "" {
 				http.Error(w, "ID cannot be empty", http.StatusBadRequest)
 				return
 			}
@@ -86,7 +141,7 @@ func GoqiteHandler(q *goqite.Queue) http.HandlerFunc {
 				return
 			}
 
-			if req.Message.ID == "" {
+			if req.Message == nil || req.Message.ID == "" {
 				http.Error(w, "ID cannot be empty", http.StatusBadRequest)
 				return
 			}
@@ -97,4 +152,90 @@ func GoqiteHandler(q *goqite.Queue) http.HandlerFunc {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+// RunnerHandler renders the jobs a [jobs.Runner] currently has in flight as JSON, for operators to
+// see what's running.
+func RunnerHandler(r *jobs.Runner) http.HandlerFunc {
+	type response struct {
+		Jobs []jobs.JobStatus
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(response{Jobs: r.InFlight()}); err != nil {
+			http.Error(w, "error encoding jobs: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// parseWait reads the long-poll duration from the `wait` query param, falling back to a
+// `Prefer: wait=<duration>` header for clients that can't set query params.
+func parseWait(r *http.Request) (time.Duration, error) {
+	wait := r.URL.Query().Get("wait")
+	if wait == "" {
+		if prefer := r.Header.Get("Prefer"); prefer != "" {
+			if rest, ok := strings.CutPrefix(prefer, "wait="); ok {
+				wait = rest
+			}
+		}
+	}
+	if wait == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(wait)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wait duration: %w", err)
+	}
+	return d, nil
+}
+
+// longPollInterval is how often receiveN polls the queue while long-polling, per
+// goqite.Queue.ReceiveAndWait's own poll-interval argument.
+const longPollInterval = 250 * time.Millisecond
+
+// receiveN receives up to n messages from q. If wait is positive and no message is immediately
+// available, it long-polls for up to wait before giving up with [context.DeadlineExceeded]. If the
+// caller's ctx is cancelled first (e.g. the client disconnected), it gives up with
+// [context.Canceled] instead. Once at least one message has been received, further messages are
+// fetched without blocking, so a slow trickle of arrivals doesn't hold the request open past its
+// first message.
+func receiveN(ctx context.Context, q *goqite.Queue, n int, wait time.Duration) ([]goqite.Message, error) {
+	if wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+
+	var ms []goqite.Message
+
+	for len(ms) < n {
+		var m *goqite.Message
+		var err error
+
+		if len(ms) == 0 && wait > 0 {
+			m, err = q.ReceiveAndWait(ctx, longPollInterval)
+		} else {
+			m, err = q.Receive(ctx)
+		}
+		if err != nil {
+			if len(ms) > 0 {
+				break
+			}
+			return nil, err
+		}
+
+		if m == nil {
+			break
+		}
+		ms = append(ms, *m)
+	}
+
+	return ms, nil
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maragudk/goqite"
+)
+
+func TestGoqiteHandler_GET(t *testing.T) {
+	t.Run("no message returns 204", func(t *testing.T) {
+		q := newTestQueue(t)
+		h := GoqiteHandler(q)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		h(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("got status %v, want %v", w.Code, http.StatusNoContent)
+		}
+	})
+
+	t.Run("receives a single message", func(t *testing.T) {
+		q := newTestQueue(t)
+		if err := q.Send(context.Background(), goqite.Message{Body: []byte("hello")}); err != nil {
+			t.Fatal(err)
+		}
+		h := GoqiteHandler(q)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		h(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %v, want %v", w.Code, http.StatusOK)
+		}
+		var res struct{ Message *goqite.Message }
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Message == nil || string(res.Message.Body) != "hello" {
+			t.Errorf("got %+v, want body %q", res.Message, "hello")
+		}
+	})
+
+	t.Run("n receives a batch", func(t *testing.T) {
+		q := newTestQueue(t)
+		for _, body := range []string{"a", "b", "c"} {
+			if err := q.Send(context.Background(), goqite.Message{Body: []byte(body)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		h := GoqiteHandler(q)
+
+		req := httptest.NewRequest(http.MethodGet, "/?n=3", nil)
+		w := httptest.NewRecorder()
+		h(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %v, want %v", w.Code, http.StatusOK)
+		}
+		var res struct{ Messages []goqite.Message }
+		if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Messages) != 3 {
+			t.Errorf("got %v messages, want 3", len(res.Messages))
+		}
+	})
+
+	t.Run("wait times out with a 504 once no message arrives", func(t *testing.T) {
+		q := newTestQueue(t)
+		h := GoqiteHandler(q)
+
+		req := httptest.NewRequest(http.MethodGet, "/?wait=50ms", nil)
+		w := httptest.NewRecorder()
+
+		start := time.Now()
+		h(w, req)
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("returned after %v, expected to wait out the full timeout", elapsed)
+		}
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("got status %v, want %v", w.Code, http.StatusGatewayTimeout)
+		}
+	})
+
+	t.Run("client disconnect while waiting returns a 408", func(t *testing.T) {
+		q := newTestQueue(t)
+		h := GoqiteHandler(q)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/?wait=1h", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		h(w, req)
+
+		if w.Code != http.StatusRequestTimeout {
+			t.Errorf("got status %v, want %v", w.Code, http.StatusRequestTimeout)
+		}
+	})
+}
+
+func TestGoqiteHandler_POST_batch(t *testing.T) {
+	q := newTestQueue(t)
+	h := GoqiteHandler(q)
+
+	body, err := json.Marshal(struct {
+		Messages []goqite.Message
+	}{
+		Messages: []goqite.Message{
+			{Body: []byte("a")},
+			{Body: []byte("b")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %v, want %v", w.Code, http.StatusOK)
+	}
+
+	for _, want := range []string{"a", "b"} {
+		m, err := q.Receive(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == nil || string(m.Body) != want {
+			t.Errorf("got %+v, want body %q", m, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/maragudk/goqite"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestQueue returns a [goqite.Queue] backed by a fresh in-memory SQLite database.
+func newTestQueue(t *testing.T) *goqite.Queue {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := goqite.Setup(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	return goqite.New(goqite.NewOpts{DB: db, Name: "test"})
+}
@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Envelope is what's actually stored in a goqite.Message's body: the job name, its payload, and
+// how many times it's been attempted so far.
+type Envelope struct {
+	Name    string
+	Payload []byte
+	// Attempt is how many times this envelope has already been attempted and failed, not counting
+	// the current attempt; it's zero for a freshly created job.
+	// Producers outside this package (e.g. a non-Go client using [JSONCodec]) can leave it unset.
+	Attempt int
+}
+
+// Codec encodes and decodes the [Envelope] stored in a goqite.Message's body. It lets job
+// payloads interoperate with producers and consumers outside this package, e.g. the JSON handler
+// in package http, or a worker written in another language.
+type Codec interface {
+	Encode(e Envelope) ([]byte, error)
+	Decode(data []byte) (Envelope, error)
+}
+
+// GobCodec encodes the envelope with encoding/gob. It's the default, and round-trips arbitrary
+// payload bytes without an intermediate text encoding.
+type GobCodec struct{}
+
+func (GobCodec) Encode(e Envelope) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Decode(data []byte) (Envelope, error) {
+	var e Envelope
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e)
+	return e, err
+}
+
+// JSONCodec encodes the envelope as JSON, with Payload base64-encoded (the encoding/json
+// default for []byte). This lets a producer in any language submit a job through an
+// [github.com/maragudk/goqite/http.GoqiteHandler] POST, without going through [Create], by using
+// `{"Name": "...", "Payload": "..."}` as the message's Body (which the handler's own JSON request
+// carries base64-encoded, since Body is a []byte).
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(e Envelope) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (JSONCodec) Decode(data []byte) (Envelope, error) {
+	var e Envelope
+	err := json.Unmarshal(data, &e)
+	return e, err
+}
+
+// RawCodec stores the payload as-is, with the job name and attempt count in a small header in
+// front of it: a one-byte name length, the name itself, and a four-byte big-endian
+// attempt count. It's the cheapest codec to produce or consume without a JSON or gob library,
+// at the cost of a 255-byte limit on job names.
+type RawCodec struct{}
+
+func (RawCodec) Encode(e Envelope) ([]byte, error) {
+	if len(e.Name) > 255 {
+		return nil, fmt.Errorf("job name %q is longer than 255 bytes", e.Name)
+	}
+
+	header := make([]byte, 1+len(e.Name)+4)
+	header[0] = byte(len(e.Name))
+	copy(header[1:], e.Name)
+	putUint32(header[1+len(e.Name):], uint32(e.Attempt))
+
+	return append(header, e.Payload...), nil
+}
+
+func (RawCodec) Decode(data []byte) (Envelope, error) {
+	if len(data) < 1 {
+		return Envelope{}, errors.New("raw envelope is empty")
+	}
+	nameLen := int(data[0])
+	if len(data) < 1+nameLen+4 {
+		return Envelope{}, errors.New("raw envelope is too short")
+	}
+
+	return Envelope{
+		Name:    string(data[1 : 1+nameLen]),
+		Attempt: int(getUint32(data[1+nameLen:])),
+		Payload: data[1+nameLen+4:],
+	}, nil
+}
+
+func putUint32(b []byte, v uint32) {
+	b[0] = byte(v >> 24)
+	b[1] = byte(v >> 16)
+	b[2] = byte(v >> 8)
+	b[3] = byte(v)
+}
+
+func getUint32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
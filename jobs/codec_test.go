@@ -0,0 +1,70 @@
+package jobs
+
+import "testing"
+
+func TestCodecs_roundTrip(t *testing.T) {
+	envelopes := []Envelope{
+		{Name: "send-email", Payload: []byte("hello"), Attempt: 0},
+		{Name: "cleanup", Payload: []byte{0x00, 0xff, 0x10}, Attempt: 3},
+		{Name: "noop", Payload: nil, Attempt: 0},
+	}
+
+	codecs := map[string]Codec{
+		"GobCodec":  GobCodec{},
+		"JSONCodec": JSONCodec{},
+		"RawCodec":  RawCodec{},
+	}
+
+	for name, c := range codecs {
+		t.Run(name, func(t *testing.T) {
+			for _, e := range envelopes {
+				data, err := c.Encode(e)
+				if err != nil {
+					t.Fatalf("Encode(%+v): %v", e, err)
+				}
+				got, err := c.Decode(data)
+				if err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				if got.Name != e.Name || got.Attempt != e.Attempt || string(got.Payload) != string(e.Payload) {
+					t.Errorf("got %+v, want %+v", got, e)
+				}
+			}
+		})
+	}
+}
+
+func TestRawCodec_Encode_nameTooLong(t *testing.T) {
+	name := make([]byte, 256)
+	for i := range name {
+		name[i] = 'a'
+	}
+	_, err := RawCodec{}.Encode(Envelope{Name: string(name)})
+	if err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func TestRawCodec_Decode_malformed(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"too short for declared name length", []byte{5, 'a', 'b'}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := (RawCodec{}).Decode(c.data); err == nil {
+				t.Error("expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestJSONCodec_Decode_invalidJSON(t *testing.T) {
+	if _, err := (JSONCodec{}).Decode([]byte("not json")); err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
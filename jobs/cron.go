@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule computes the next time a spec should fire, given the previous fire time (or the time
+// the schedule was created, for the first run).
+type schedule interface {
+	next(from time.Time) time.Time
+}
+
+// parseSpec parses spec as either a [time.Duration] (e.g. "5m", "30s") for a fixed interval, or a
+// 5-field cron expression ("minute hour day-of-month month day-of-week"), where each field is
+// "*", a number, a comma-separated list of numbers, or a "*/step". As in standard cron, if both
+// day-of-month and day-of-week are restricted (neither is "*"), a date matches when either one
+// does, not only when both do: "0 0 1 * 1" fires at midnight on the 1st of the month, or on any
+// Monday.
+func parseSpec(spec string) (schedule, error) {
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("interval must be positive, got %v", d)
+		}
+		return intervalSchedule{interval: d}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(`invalid spec %q: must be a duration or a 5-field cron expression`, spec)
+	}
+
+	var c cronSchedule
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute field: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour field: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day-of-month field: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month field: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 6); err != nil {
+		return nil, fmt.Errorf("day-of-week field: %w", err)
+	}
+	return c, nil
+}
+
+// intervalSchedule fires every interval, relative to the last fire time.
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+func (s intervalSchedule) next(from time.Time) time.Time {
+	return from.Add(s.interval)
+}
+
+// cronSchedule is a standard 5-field cron expression. Each field is a set of allowed values; a nil
+// set means "any value" (a "*").
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+}
+
+func (s cronSchedule) next(from time.Time) time.Time {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	// A year is well beyond any realistic cron cadence, and bounds the search in case of a
+	// day-of-month/month combination that can never match (e.g. Feb 30).
+	for limit := t.AddDate(1, 0, 0); t.Before(limit); t = t.Add(time.Minute) {
+		if cronFieldMatches(s.minute, t.Minute()) &&
+			cronFieldMatches(s.hour, t.Hour()) &&
+			domDowMatches(s.dom, s.dow, t) &&
+			cronFieldMatches(s.month, int(t.Month())) {
+			return t
+		}
+	}
+	return t
+}
+
+func cronFieldMatches(field map[int]bool, v int) bool {
+	return field == nil || field[v]
+}
+
+// domDowMatches applies the standard cron rule for day-of-month and day-of-week: if only one of
+// them is restricted (not "*"), it alone decides the match; if both are restricted, a match on
+// either is enough. Without this, a spec like "0 0 1 * 1" would only fire when the 1st happens to
+// land on a Monday, instead of on the 1st or any Monday.
+func domDowMatches(dom, dow map[int]bool, t time.Time) bool {
+	if dom == nil || dow == nil {
+		return cronFieldMatches(dom, t.Day()) && cronFieldMatches(dow, int(t.Weekday()))
+	}
+	return dom[t.Day()] || dow[int(t.Weekday())]
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	if field == "*" {
+		return nil, nil
+	}
+
+	if rest, ok := strings.CutPrefix(field, "*/"); ok {
+		step, err := strconv.Atoi(rest)
+		if err != nil || step <= 0 {
+			return nil, fmt.Errorf("invalid step %q", field)
+		}
+		values := map[int]bool{}
+		for v := min; v <= max; v += step {
+			values[v] = true
+		}
+		return values, nil
+	}
+
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		if v < min || v > max {
+			return nil, fmt.Errorf("value %v out of range [%v,%v]", v, min, max)
+		}
+		values[v] = true
+	}
+	return values, nil
+}
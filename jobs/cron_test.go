@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSpec(t *testing.T) {
+	t.Run("duration", func(t *testing.T) {
+		sch, err := parseSpec("5m")
+		if err != nil {
+			t.Fatal(err)
+		}
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		want := from.Add(5 * time.Minute)
+		if got := sch.next(from); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("non-positive duration is rejected", func(t *testing.T) {
+		if _, err := parseSpec("0s"); err == nil {
+			t.Error("expected an error, got nil")
+		}
+		if _, err := parseSpec("-1m"); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+
+	t.Run("5-field cron expression", func(t *testing.T) {
+		sch, err := parseSpec("30 4 * * *")
+		if err != nil {
+			t.Fatal(err)
+		}
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		want := time.Date(2026, 1, 1, 4, 30, 0, 0, time.UTC)
+		if got := sch.next(from); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("wrong number of fields is rejected", func(t *testing.T) {
+		if _, err := parseSpec("* * *"); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+
+	t.Run("invalid field is rejected", func(t *testing.T) {
+		if _, err := parseSpec("60 * * * *"); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
+
+func TestCronSchedule_next(t *testing.T) {
+	cases := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "every minute",
+			spec: "* * * * *",
+			from: time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 12, 1, 0, 0, time.UTC),
+		},
+		{
+			name: "step minutes",
+			spec: "*/15 * * * *",
+			from: time.Date(2026, 1, 1, 12, 1, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 12, 15, 0, 0, time.UTC),
+		},
+		{
+			name: "specific day of week",
+			spec: "0 9 * * 1",
+			from: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), // a Thursday
+			want: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC), // the following Monday
+		},
+		{
+			name: "comma list",
+			spec: "0 0,12 * * *",
+			from: time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			// Standard cron ORs day-of-month and day-of-week when both are restricted: this
+			// should fire on the nearest of "the 1st of the month" or "a Monday", not only when
+			// the two coincide.
+			name: "day-of-month or day-of-week, whichever comes first",
+			spec: "0 0 1 * 1",
+			from: time.Date(2026, 1, 1, 0, 0, 30, 0, time.UTC), // just after midnight on the 1st
+			want: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),  // the following Monday, not Feb 1
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sch, err := parseSpec(c.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sch.next(c.from); !got.Equal(c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseCronField(t *testing.T) {
+	t.Run("star means any value", func(t *testing.T) {
+		field, err := parseCronField("*", 0, 59)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if field != nil {
+			t.Errorf("got %v, want nil", field)
+		}
+	})
+
+	t.Run("value out of range is rejected", func(t *testing.T) {
+		if _, err := parseCronField("99", 0, 59); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+
+	t.Run("invalid step is rejected", func(t *testing.T) {
+		if _, err := parseCronField("*/0", 0, 59); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
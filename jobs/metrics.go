@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics receives lifecycle events from a [Runner], for observability. Implementations must be
+// safe for concurrent use.
+type Metrics interface {
+	JobStarted(name string)
+	JobFinished(name string, duration time.Duration)
+	JobFailed(name string, err error)
+	JobRetried(name string, attempt int)
+
+	// QueueDepth isn't called by [Runner] itself, since goqite.Queue doesn't expose a count of
+	// waiting messages; it's here for callers that track depth themselves (e.g. by querying the
+	// underlying database) to report it through the same [Metrics] implementation.
+	QueueDepth(name string, n int)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) JobStarted(name string)                          {}
+func (noopMetrics) JobFinished(name string, duration time.Duration) {}
+func (noopMetrics) JobFailed(name string, err error)                {}
+func (noopMetrics) JobRetried(name string, attempt int)             {}
+func (noopMetrics) QueueDepth(name string, n int)                   {}
+
+// PrometheusMetrics is a [Metrics] implementation that keeps counters, a duration histogram, and
+// in-flight gauges in memory, and can render them in the Prometheus text exposition format with
+// [PrometheusMetrics.WriteTo].
+type PrometheusMetrics struct {
+	mu sync.Mutex
+
+	started  map[string]int
+	finished map[string]int
+	failed   map[string]int
+	retried  map[string]int
+	inFlight map[string]int
+	depth    map[string]int
+
+	// durationBuckets are the histogram bucket upper bounds, in seconds.
+	durationBuckets []float64
+	// durationCounts[name][bucketIndex] is the number of finished jobs with a duration <= the
+	// bucket's upper bound.
+	durationCounts map[string][]int
+	durationSum    map[string]float64
+}
+
+// NewPrometheusMetrics returns a [PrometheusMetrics] with a reasonable default set of duration
+// histogram buckets, in seconds.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		started:         map[string]int{},
+		finished:        map[string]int{},
+		failed:          map[string]int{},
+		retried:         map[string]int{},
+		inFlight:        map[string]int{},
+		depth:           map[string]int{},
+		durationBuckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60},
+		durationCounts:  map[string][]int{},
+		durationSum:     map[string]float64{},
+	}
+}
+
+func (m *PrometheusMetrics) JobStarted(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started[name]++
+	m.inFlight[name]++
+}
+
+func (m *PrometheusMetrics) JobFinished(name string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished[name]++
+	m.inFlight[name]--
+
+	if _, ok := m.durationCounts[name]; !ok {
+		m.durationCounts[name] = make([]int, len(m.durationBuckets))
+	}
+	seconds := duration.Seconds()
+	for i, bucket := range m.durationBuckets {
+		if seconds <= bucket {
+			m.durationCounts[name][i]++
+		}
+	}
+	m.durationSum[name] += seconds
+}
+
+func (m *PrometheusMetrics) JobFailed(name string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed[name]++
+	m.inFlight[name]--
+}
+
+func (m *PrometheusMetrics) JobRetried(name string, attempt int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retried[name]++
+}
+
+func (m *PrometheusMetrics) QueueDepth(name string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depth[name] = n
+}
+
+// WriteTo renders the collected metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	write := func(format string, args ...any) {
+		written, _ := fmt.Fprintf(w, format, args...)
+		n += int64(written)
+	}
+
+	writeCounter := func(metric, help string, counts map[string]int) {
+		write("# HELP %v %v\n# TYPE %v counter\n", metric, help, metric)
+		for _, name := range sortedKeys(counts) {
+			write("%v{job=%q} %v\n", metric, name, counts[name])
+		}
+	}
+
+	writeCounter("goqite_jobs_started_total", "Total number of jobs started.", m.started)
+	writeCounter("goqite_jobs_finished_total", "Total number of jobs finished successfully.", m.finished)
+	writeCounter("goqite_jobs_failed_total", "Total number of jobs that returned an error or panicked.", m.failed)
+	writeCounter("goqite_jobs_retried_total", "Total number of job retries enqueued.", m.retried)
+
+	write("# HELP goqite_jobs_in_flight Number of jobs currently running.\n# TYPE goqite_jobs_in_flight gauge\n")
+	for _, name := range sortedKeys(m.inFlight) {
+		write("goqite_jobs_in_flight{job=%q} %v\n", name, m.inFlight[name])
+	}
+
+	write("# HELP goqite_queue_depth Approximate number of messages waiting in the queue.\n# TYPE goqite_queue_depth gauge\n")
+	for _, name := range sortedKeys(m.depth) {
+		write("goqite_queue_depth{job=%q} %v\n", name, m.depth[name])
+	}
+
+	write("# HELP goqite_job_duration_seconds Job run duration in seconds.\n# TYPE goqite_job_duration_seconds histogram\n")
+	for _, name := range sortedKeys(m.durationCounts) {
+		counts := m.durationCounts[name]
+		for i, bucket := range m.durationBuckets {
+			write("goqite_job_duration_seconds_bucket{job=%q,le=\"%v\"} %v\n", name, bucket, counts[i])
+		}
+		write("goqite_job_duration_seconds_bucket{job=%q,le=\"+Inf\"} %v\n", name, m.finished[name])
+		write("goqite_job_duration_seconds_sum{job=%q} %v\n", name, m.durationSum[name])
+		write("goqite_job_duration_seconds_count{job=%q} %v\n", name, m.finished[name])
+	}
+
+	return n, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	m := NewPrometheusMetrics()
+
+	m.JobStarted("send-email")
+	m.JobStarted("send-email")
+	m.JobFinished("send-email", 20*time.Millisecond)
+	m.JobFailed("send-email", errors.New("boom"))
+	m.JobRetried("send-email", 1)
+	m.QueueDepth("send-email", 3)
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`goqite_jobs_started_total{job="send-email"} 2`,
+		`goqite_jobs_finished_total{job="send-email"} 1`,
+		`goqite_jobs_failed_total{job="send-email"} 1`,
+		`goqite_jobs_retried_total{job="send-email"} 1`,
+		`goqite_jobs_in_flight{job="send-email"} 0`,
+		`goqite_queue_depth{job="send-email"} 3`,
+		`goqite_job_duration_seconds_bucket{job="send-email",le="0.05"} 1`,
+		`goqite_job_duration_seconds_count{job="send-email"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%v", want, out)
+		}
+	}
+}
+
+func TestPrometheusMetrics_inFlight(t *testing.T) {
+	m := NewPrometheusMetrics()
+
+	m.JobStarted("a")
+	m.JobStarted("a")
+	m.JobFinished("a", time.Millisecond)
+	m.JobFailed("a", errors.New("boom"))
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `goqite_jobs_in_flight{job="a"} 0`) {
+		t.Errorf("expected in-flight to return to 0 once started jobs finish or fail, got:\n%v", buf.String())
+	}
+}
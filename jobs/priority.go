@@ -0,0 +1,30 @@
+package jobs
+
+import "github.com/maragudk/goqite"
+
+// queueTier is one priority tier a [Runner] polls: its own goqite.Queue, and how many goroutines
+// poll it concurrently. Each poller runs completely independently of the others (see
+// Runner.Start), so a tier that's backed off — e.g. because a job name on it is at its
+// MaxConcurrent cap — can never stall receives on a different tier the way a single shared
+// dispatch loop picking one queue at a time would.
+//
+// count is how a tier's weight (relative to the primary queue's implicit weight of 1) turns into
+// its share of receives: a weight-4 tier gets 4 concurrent pollers against the primary's 1, so it
+// gets roughly 4x the receive throughput.
+type queueTier struct {
+	queue *goqite.Queue
+	count int
+}
+
+// buildQueueTiers turns primary and extra into the tiers a Runner polls, see [queueTier].
+func buildQueueTiers(primary *goqite.Queue, extra []PriorityQueue) []queueTier {
+	tiers := []queueTier{{queue: primary, count: 1}}
+	for _, pq := range extra {
+		weight := pq.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		tiers = append(tiers, queueTier{queue: pq.Queue, count: weight})
+	}
+	return tiers
+}
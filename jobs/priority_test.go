@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/maragudk/goqite"
+)
+
+func TestBuildQueueTiers(t *testing.T) {
+	t.Run("primary-only", func(t *testing.T) {
+		primary := &goqite.Queue{}
+		tiers := buildQueueTiers(primary, nil)
+		if len(tiers) != 1 {
+			t.Fatalf("got %v tiers, want 1", len(tiers))
+		}
+		if tiers[0].queue != primary || tiers[0].count != 1 {
+			t.Errorf("got %+v, want {queue: primary, count: 1}", tiers[0])
+		}
+	})
+
+	t.Run("weight becomes poller count", func(t *testing.T) {
+		primary := &goqite.Queue{}
+		high := &goqite.Queue{}
+		tiers := buildQueueTiers(primary, []PriorityQueue{{Queue: high, Weight: 4}})
+
+		if len(tiers) != 2 {
+			t.Fatalf("got %v tiers, want 2", len(tiers))
+		}
+		if tiers[1].queue != high || tiers[1].count != 4 {
+			t.Errorf("got %+v, want {queue: high, count: 4}", tiers[1])
+		}
+	})
+
+	t.Run("non-positive weight defaults to 1", func(t *testing.T) {
+		primary := &goqite.Queue{}
+		extra := &goqite.Queue{}
+		tiers := buildQueueTiers(primary, []PriorityQueue{{Queue: extra, Weight: 0}})
+
+		if tiers[1].count != 1 {
+			t.Errorf("got count %v, want 1", tiers[1].count)
+		}
+	})
+
+	t.Run("same-weight entries don't collide", func(t *testing.T) {
+		primary := &goqite.Queue{}
+		a := &goqite.Queue{}
+		b := &goqite.Queue{}
+		tiers := buildQueueTiers(primary, []PriorityQueue{
+			{Queue: a, Weight: 2},
+			{Queue: b, Weight: 2},
+		})
+
+		if len(tiers) != 3 {
+			t.Fatalf("got %v tiers, want 3 (both same-weight queues kept)", len(tiers))
+		}
+		if tiers[1].queue != a || tiers[2].queue != b {
+			t.Errorf("got tiers %+v, want a then b preserved in order", tiers)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/maragudk/goqite"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestDB opens an in-memory SQLite database for a single test, closed on cleanup.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := goqite.Setup(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestQueue returns a [goqite.Queue] named name backed by db.
+func newTestQueue(t *testing.T, db *sql.DB, name string) *goqite.Queue {
+	t.Helper()
+
+	return goqite.New(goqite.NewOpts{
+		DB:   db,
+		Name: goqite.Name(name),
+	})
+}
@@ -0,0 +1,137 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"time"
+
+	"github.com/maragudk/goqite"
+)
+
+// RetryPolicy controls how a job is retried when its Func returns an error or panics.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times a job is attempted before it's moved to the
+	// dead-letter queue. Zero means use [DefaultRetryPolicy]'s value.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. It doubles with each subsequent attempt.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the computed backoff delay, regardless of attempt count.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction (0-1) of the computed delay that's randomly added or subtracted,
+	// to avoid thundering-herd retries.
+	Jitter float64
+
+	// AttemptTimeout bounds a single attempt at running the job. Zero means no per-attempt
+	// timeout beyond the context passed to Start.
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy is used by [Runner.Register] and by [Runner.RegisterWithOptions] when
+// JobOptions.RetryPolicy is the zero value.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Second,
+	MaxDelay:    time.Minute,
+	Jitter:      0.1,
+}
+
+// delay returns the backoff delay after the given number of failed attempts, i.e. before retry
+// number attempt (1-indexed).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// JobOptions configures how a job registered with [Runner.RegisterWithOptions] is run and retried.
+type JobOptions struct {
+	// RetryPolicy controls the backoff between retries and the max attempts. The zero value uses
+	// [DefaultRetryPolicy].
+	RetryPolicy RetryPolicy
+
+	// DeadLetterQueue receives the payload, along with the last error and a stack trace (for
+	// panics), once RetryPolicy.MaxAttempts attempts have failed. If nil, the message is just dropped.
+	DeadLetterQueue *goqite.Queue
+
+	// MaxConcurrent caps how many jobs with this name the Runner will run at once, regardless of
+	// NewRunnerOpts.Limit. Zero means no per-name cap.
+	MaxConcurrent int
+}
+
+func (o JobOptions) retryPolicy() RetryPolicy {
+	if o.RetryPolicy == (RetryPolicy{}) {
+		return DefaultRetryPolicy
+	}
+	p := o.RetryPolicy
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	return p
+}
+
+// DeadLetter is the payload sent to a JobOptions.DeadLetterQueue once a job has exhausted its
+// retry policy. It's always gob-encoded, regardless of the Runner's configured [Codec], since it
+// carries diagnostic fields that [Envelope] has no room for.
+type DeadLetter struct {
+	Name    string
+	Payload []byte
+	Attempt int
+	Error   string
+	Stack   string
+}
+
+// runJob runs job, recovering from a panic and turning it into an error so panics and regular
+// job errors feed into the same retry accounting.
+func runJob(ctx context.Context, job Func, m []byte) (err error, stack string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic in job: %v", rec)
+			stack = string(debug.Stack())
+		}
+	}()
+	return job(ctx, m), ""
+}
+
+// handleFailure either re-enqueues jm with a backoff delay, or, once opts' retry policy is
+// exhausted, moves it to the dead-letter queue (if any). It's called for both job errors and
+// panics, so they share the same attempt accounting.
+func (r *Runner) handleFailure(ctx context.Context, queue *goqite.Queue, opts JobOptions, jm Envelope, jobErr error, stack string) error {
+	policy := opts.retryPolicy()
+	jm.Attempt++
+
+	if jm.Attempt < policy.MaxAttempts {
+		return Create(ctx, queue, jm.Name, jm.Payload, WithCreateCodec(r.codec), WithCreateDelay(policy.delay(jm.Attempt)), withCreateAttempt(jm.Attempt))
+	}
+
+	if opts.DeadLetterQueue == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(DeadLetter{
+		Name:    jm.Name,
+		Payload: jm.Payload,
+		Attempt: jm.Attempt,
+		Error:   jobErr.Error(),
+		Stack:   stack,
+	}); err != nil {
+		return err
+	}
+	return opts.DeadLetterQueue.Send(ctx, goqite.Message{Body: buf.Bytes()})
+}
@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_delay(t *testing.T) {
+	t.Run("doubles with each attempt", func(t *testing.T) {
+		p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Hour}
+		if got := p.delay(1); got != time.Second {
+			t.Errorf("got %v, want %v", got, time.Second)
+		}
+		if got := p.delay(2); got != 2*time.Second {
+			t.Errorf("got %v, want %v", got, 2*time.Second)
+		}
+		if got := p.delay(3); got != 4*time.Second {
+			t.Errorf("got %v, want %v", got, 4*time.Second)
+		}
+	})
+
+	t.Run("caps at MaxDelay", func(t *testing.T) {
+		p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+		if got := p.delay(10); got != 5*time.Second {
+			t.Errorf("got %v, want %v", got, 5*time.Second)
+		}
+	})
+
+	t.Run("jitter stays within bounds and never negative", func(t *testing.T) {
+		p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			got := p.delay(1)
+			if got < 0 {
+				t.Fatalf("got negative delay %v", got)
+			}
+			if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+				t.Fatalf("got %v, want within 50%% of %v", got, time.Second)
+			}
+		}
+	})
+}
+
+func TestJobOptions_retryPolicy(t *testing.T) {
+	t.Run("zero value falls back to DefaultRetryPolicy", func(t *testing.T) {
+		var o JobOptions
+		if got := o.retryPolicy(); got != DefaultRetryPolicy {
+			t.Errorf("got %+v, want %+v", got, DefaultRetryPolicy)
+		}
+	})
+
+	t.Run("explicit policy is used as-is", func(t *testing.T) {
+		custom := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+		o := JobOptions{RetryPolicy: custom}
+		if got := o.retryPolicy(); got != custom {
+			t.Errorf("got %+v, want %+v", got, custom)
+		}
+	})
+}
+
+// TestHandleFailure_deadLettersAfterMaxAttempts fails the same job repeatedly and checks that the
+// attempt count survives each re-enqueue (the envelope is re-decoded from the queue after every
+// failure, not just tracked in memory), and that the job is only moved to the dead-letter queue
+// once MaxAttempts is actually exhausted.
+func TestHandleFailure_deadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	queue := newTestQueue(t, db, "jobs")
+	dlq := newTestQueue(t, db, "dead-letters")
+
+	r := &Runner{codec: GobCodec{}}
+	opts := JobOptions{
+		RetryPolicy:     RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		DeadLetterQueue: dlq,
+	}
+
+	jm := Envelope{Name: "send-email", Payload: []byte("hello")}
+
+	for want := 1; want <= 2; want++ {
+		if err := r.handleFailure(ctx, queue, opts, jm, errors.New("boom"), ""); err != nil {
+			t.Fatalf("attempt %v: %v", want, err)
+		}
+
+		m, err := queue.Receive(ctx)
+		if err != nil {
+			t.Fatalf("attempt %v: %v", want, err)
+		}
+		if m == nil {
+			t.Fatalf("attempt %v: expected a re-enqueued message, got none", want)
+		}
+
+		got, err := r.codec.Decode(m.Body)
+		if err != nil {
+			t.Fatalf("attempt %v: %v", want, err)
+		}
+		if got.Attempt != want {
+			t.Errorf("attempt %v: got Envelope.Attempt %v, want %v", want, got.Attempt, want)
+		}
+		jm = got
+	}
+
+	// The third failure exhausts MaxAttempts, so it should be dead-lettered instead of
+	// re-enqueued.
+	if err := r.handleFailure(ctx, queue, opts, jm, errors.New("boom"), "stack trace"); err != nil {
+		t.Fatal(err)
+	}
+
+	if m, err := queue.Receive(ctx); err != nil {
+		t.Fatal(err)
+	} else if m != nil {
+		t.Error("expected no further re-enqueue once MaxAttempts is exhausted")
+	}
+
+	dead, err := dlq.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dead == nil {
+		t.Fatal("expected a dead-lettered message, got none")
+	}
+
+	var dl DeadLetter
+	if err := gob.NewDecoder(bytes.NewReader(dead.Body)).Decode(&dl); err != nil {
+		t.Fatal(err)
+	}
+	if dl.Name != "send-email" {
+		t.Errorf("got DeadLetter.Name %v, want send-email", dl.Name)
+	}
+	if dl.Attempt != 3 {
+		t.Errorf("got DeadLetter.Attempt %v, want 3", dl.Attempt)
+	}
+	if dl.Error != "boom" {
+		t.Errorf("got DeadLetter.Error %v, want boom", dl.Error)
+	}
+}
@@ -5,17 +5,19 @@
 // - Limit on how many jobs can be run simultaneously
 // - Automatic message timeout extension while the job is running
 // - Graceful shutdown
+// - Configurable retries with backoff and a dead-letter queue, see [JobOptions]
+// - Recurring and one-shot scheduled jobs on top of the same queue, see [Scheduler]
+// - Priority queues and a per-job-name concurrency cap, see NewRunnerOpts.PriorityQueues and JobOptions.MaxConcurrent
 package jobs
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"errors"
 	"fmt"
 	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/maragudk/goqite"
@@ -26,6 +28,29 @@ type NewRunnerOpts struct {
 	Log          logger
 	PollInterval time.Duration
 	Queue        *goqite.Queue
+
+	// Codec encodes and decodes job envelopes. Default [GobCodec].
+	Codec Codec
+
+	// Metrics receives job lifecycle events. Default is a no-op. See [PrometheusMetrics] for a
+	// ready-made implementation.
+	Metrics Metrics
+
+	// PriorityQueues are additional queues the Runner polls concurrently with Queue, each with a
+	// weight relative to Queue's implicit weight of 1. A job's priority is which queue it's sent
+	// to with [Create], not a field on the job itself: goqite.Message already has its own
+	// Priority field that orders receives within a single queue, but that doesn't help two
+	// different classes of job share one queue without one starving the other's poll attempts.
+	// Giving a class of job its own queue, polled by more goroutines the higher its weight, does:
+	// a queue with weight 4 here gets about 4x as many concurrent pollers (and so receives) as
+	// Queue. Multiple entries may share the same weight.
+	PriorityQueues []PriorityQueue
+}
+
+// PriorityQueue is one additional priority tier for a [Runner], see NewRunnerOpts.PriorityQueues.
+type PriorityQueue struct {
+	Queue  *goqite.Queue
+	Weight int
 }
 
 func NewRunner(opts NewRunnerOpts) *Runner {
@@ -41,30 +66,78 @@ func NewRunner(opts NewRunnerOpts) *Runner {
 		opts.PollInterval = 100 * time.Millisecond
 	}
 
+	if opts.Codec == nil {
+		opts.Codec = GobCodec{}
+	}
+
+	if opts.Metrics == nil {
+		opts.Metrics = noopMetrics{}
+	}
+
 	return &Runner{
+		codec:         opts.Codec,
 		jobCountLimit: opts.Limit,
-		jobs:          make(map[string]Func),
+		jobs:          make(map[string]*registration),
 		log:           opts.Log,
+		metrics:       opts.Metrics,
 		pollInterval:  opts.PollInterval,
 		queue:         opts.Queue,
+		tiers:         buildQueueTiers(opts.Queue, opts.PriorityQueues),
 	}
 }
 
 type Runner struct {
+	codec         Codec
+	inFlight      map[string]JobStatus
+	inFlightLock  sync.RWMutex
 	jobCount      int
 	jobCountLimit int
 	jobCountLock  sync.RWMutex
-	jobs          map[string]Func
+	jobs          map[string]*registration
 	log           logger
+	metrics       Metrics
 	pollInterval  time.Duration
 	queue         *goqite.Queue
+	tiers         []queueTier
 }
 
-type message struct {
-	Name    string
-	Message []byte
+// JobStatus describes a job currently being run by a [Runner], as returned by [Runner.InFlight].
+type JobStatus struct {
+	Name      string
+	MessageID string
+	StartedAt time.Time
+	Attempt   int
 }
 
+// InFlight returns the jobs currently being run by the Runner.
+func (r *Runner) InFlight() []JobStatus {
+	r.inFlightLock.RLock()
+	defer r.inFlightLock.RUnlock()
+
+	statuses := make([]JobStatus, 0, len(r.inFlight))
+	for _, s := range r.inFlight {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].StartedAt.Before(statuses[j].StartedAt) })
+	return statuses
+}
+
+type registration struct {
+	job     Func
+	options JobOptions
+	// sem caps concurrent runs of this job by name, when options.MaxConcurrent > 0. Acquired
+	// before a received message is run, released when it finishes.
+	sem chan struct{}
+	// capBackoff is how long receiveAndRun last waited after dequeuing a message for this job
+	// while it was at its MaxConcurrent cap, in nanoseconds. It doubles on each consecutive
+	// cap hit (up to maxCapBackoff) and resets to zero once a message is successfully acquired,
+	// so a saturated job name backs off instead of busy-looping on its own in-flight messages.
+	capBackoff atomic.Int64
+}
+
+// maxCapBackoff bounds how long receiveAndRun will extend and sleep on a single over-cap message.
+const maxCapBackoff = 30 * time.Second
+
 // Start the Runner, blocking until the given context is cancelled.
 // When the context is cancelled, waits for the jobs to finish.
 func (r *Runner) Start(ctx context.Context) {
@@ -76,22 +149,38 @@ func (r *Runner) Start(ctx context.Context) {
 
 	r.log.Info("Starting", "jobs", names)
 
-	var wg sync.WaitGroup
-
-	for {
-		select {
-		case <-ctx.Done():
-			r.log.Info("Stopping")
-			wg.Wait()
-			r.log.Info("Stopped")
-			return
-		default:
-			r.receiveAndRun(ctx, &wg)
+	var jobWG sync.WaitGroup
+	var pollerWG sync.WaitGroup
+
+	// Each tier is polled by its own, independent goroutine(s), rather than a single loop
+	// round-robining between tiers. That way a tier that's backed off (e.g. everything it just
+	// received is over its MaxConcurrent cap) only slows down its own pollers, never the other
+	// tiers'.
+	for _, tier := range r.tiers {
+		for i := 0; i < tier.count; i++ {
+			pollerWG.Add(1)
+			go func(queue *goqite.Queue) {
+				defer pollerWG.Done()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					default:
+						r.receiveAndRun(ctx, queue, &jobWG)
+					}
+				}
+			}(tier.queue)
 		}
 	}
+
+	<-ctx.Done()
+	r.log.Info("Stopping")
+	pollerWG.Wait()
+	jobWG.Wait()
+	r.log.Info("Stopped")
 }
 
-func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
+func (r *Runner) receiveAndRun(ctx context.Context, queue *goqite.Queue, wg *sync.WaitGroup) {
 	r.jobCountLock.RLock()
 	if r.jobCount == r.jobCountLimit {
 		r.jobCountLock.RUnlock()
@@ -102,7 +191,7 @@ func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 		r.jobCountLock.RUnlock()
 	}
 
-	m, err := r.queue.ReceiveAndWait(ctx, r.pollInterval)
+	m, err := queue.ReceiveAndWait(ctx, r.pollInterval)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 			return
@@ -117,17 +206,44 @@ func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 		return
 	}
 
-	var jm message
-	if err := gob.NewDecoder(bytes.NewReader(m.Body)).Decode(&jm); err != nil {
+	jm, err := r.codec.Decode(m.Body)
+	if err != nil {
 		r.log.Info("Error decoding job message body", "error", err)
 		return
 	}
 
-	job, ok := r.jobs[jm.Name]
+	reg, ok := r.jobs[jm.Name]
 	if !ok {
 		panic(fmt.Sprintf(`job "%v" not registered`, jm.Name))
 	}
 
+	// goqite has no receive-side name filter, so we've already dequeued a message we might not be
+	// able to run right now. If this job name is at its MaxConcurrent cap, the best we can do
+	// without such a filter is give it back via Extend. We back off (and sleep) for as long as we
+	// extend by, and double the backoff on each consecutive cap hit for this name, so a saturated
+	// job name doesn't immediately re-dequeue and re-release the same message in a tight loop that
+	// starves every other name sharing the queue.
+	if reg.sem != nil {
+		select {
+		case reg.sem <- struct{}{}:
+			reg.capBackoff.Store(0)
+		default:
+			backoff := reg.capBackoff.Load()
+			if backoff == 0 {
+				backoff = int64(r.pollInterval)
+			} else if backoff*2 <= int64(maxCapBackoff) {
+				backoff *= 2
+			}
+			reg.capBackoff.Store(backoff)
+
+			if err := queue.Extend(ctx, m.ID, time.Duration(backoff)); err != nil {
+				r.log.Info("Error releasing message over its concurrency limit", "name", jm.Name, "error", err)
+			}
+			time.Sleep(time.Duration(backoff))
+			return
+		}
+	}
+
 	r.jobCountLock.Lock()
 	r.jobCount++
 	r.jobCountLock.Unlock()
@@ -142,6 +258,10 @@ func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 			r.jobCountLock.Unlock()
 		}()
 
+		if reg.sem != nil {
+			defer func() { <-reg.sem }()
+		}
+
 		defer func() {
 			if rec := recover(); rec != nil {
 				r.log.Info("Recovered from panic in job", "error", rec)
@@ -151,6 +271,11 @@ func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 		jobCtx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
+		if timeout := reg.options.retryPolicy().AttemptTimeout; timeout > 0 {
+			jobCtx, cancel = context.WithTimeout(jobCtx, timeout)
+			defer cancel()
+		}
+
 		// Extend the job message while the job is running
 		done := make(chan struct{}, 1)
 		defer func() {
@@ -163,7 +288,7 @@ func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 				case <-done:
 					return
 				default:
-					if err := r.queue.Extend(jobCtx, m.ID, 5*time.Second); err != nil {
+					if err := queue.Extend(jobCtx, m.ID, 5*time.Second); err != nil {
 						r.log.Info("Error extending message timeout", "error", err)
 					}
 					time.Sleep(3 * time.Second)
@@ -171,38 +296,117 @@ func (r *Runner) receiveAndRun(ctx context.Context, wg *sync.WaitGroup) {
 			}
 		}()
 
+		deleteCtx := context.Background()
+		defer func() {
+			deleteCtx, cancel := context.WithTimeout(deleteCtx, time.Second)
+			defer cancel()
+			if err := queue.Delete(deleteCtx, m.ID); err != nil {
+				r.log.Info("Error deleting job from queue", "error", err)
+			}
+		}()
+
 		before := time.Now()
-		if err := job(jobCtx, jm.Message); err != nil {
-			r.log.Info("Error running job", "name", jm.Name, "error", err)
+		r.metrics.JobStarted(jm.Name)
+		r.setInFlight(m, jm, before)
+		defer r.clearInFlight(m)
+
+		if err, stack := runJob(jobCtx, reg.job, jm.Payload); err != nil {
+			r.log.Info("Error running job", "name", jm.Name, "attempt", jm.Attempt+1, "error", err)
+			r.metrics.JobFailed(jm.Name, err)
+			if failErr := r.handleFailure(deleteCtx, queue, reg.options, jm, err, stack); failErr != nil {
+				r.log.Info("Error handling job failure", "name", jm.Name, "error", failErr)
+			} else if jm.Attempt+1 < reg.options.retryPolicy().MaxAttempts {
+				r.metrics.JobRetried(jm.Name, jm.Attempt+1)
+			}
 			return
 		}
 		duration := time.Since(before)
+		r.metrics.JobFinished(jm.Name, duration)
 		r.log.Info("Ran job", "name", jm.Name, "duration", duration)
-
-		deleteCtx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		if err := r.queue.Delete(deleteCtx, m.ID); err != nil {
-			r.log.Info("Error deleting job from queue", "error", err)
-		}
 	}()
 }
 
+func (r *Runner) setInFlight(m *goqite.Message, jm Envelope, startedAt time.Time) {
+	r.inFlightLock.Lock()
+	defer r.inFlightLock.Unlock()
+	if r.inFlight == nil {
+		r.inFlight = map[string]JobStatus{}
+	}
+	r.inFlight[fmt.Sprint(m.ID)] = JobStatus{
+		Name:      jm.Name,
+		MessageID: fmt.Sprint(m.ID),
+		StartedAt: startedAt,
+		Attempt:   jm.Attempt + 1,
+	}
+}
+
+func (r *Runner) clearInFlight(m *goqite.Message) {
+	r.inFlightLock.Lock()
+	defer r.inFlightLock.Unlock()
+	delete(r.inFlight, fmt.Sprint(m.ID))
+}
+
 // Func is a job to be done. It gets the message m from the queue.
 type Func func(ctx context.Context, m []byte) error
 
+// Register job under name, using [DefaultRetryPolicy] and no dead-letter queue.
+// See [Runner.RegisterWithOptions] to customize retry behaviour.
 func (r *Runner) Register(name string, job Func) {
+	r.RegisterWithOptions(name, job, JobOptions{})
+}
+
+// RegisterWithOptions registers job under name, with opts controlling its retry policy and
+// dead-letter queue.
+func (r *Runner) RegisterWithOptions(name string, job Func, opts JobOptions) {
 	if _, ok := r.jobs[name]; ok {
 		panic(fmt.Sprintf(`job "%v" already registered`, name))
 	}
-	r.jobs[name] = job
+
+	reg := &registration{job: job, options: opts}
+	if opts.MaxConcurrent > 0 {
+		reg.sem = make(chan struct{}, opts.MaxConcurrent)
+	}
+	r.jobs[name] = reg
+}
+
+// CreateOption configures [Create].
+type CreateOption func(*createOptions)
+
+type createOptions struct {
+	codec   Codec
+	delay   time.Duration
+	attempt int
+}
+
+// WithCreateCodec sets the [Codec] used to encode the job envelope. It must match the [Codec]
+// the consuming [Runner] was configured with. Default [GobCodec].
+func WithCreateCodec(c Codec) CreateOption {
+	return func(o *createOptions) { o.codec = c }
+}
+
+// WithCreateDelay sets how long the queue waits before the job becomes visible to a [Runner].
+func WithCreateDelay(d time.Duration) CreateOption {
+	return func(o *createOptions) { o.delay = d }
+}
+
+// withCreateAttempt sets the attempt count recorded in the job envelope. It's used internally by
+// handleFailure to carry the attempt count across a retry's re-enqueue; callers creating a fresh
+// job have no reason to set it.
+func withCreateAttempt(attempt int) CreateOption {
+	return func(o *createOptions) { o.attempt = attempt }
 }
 
-func Create(ctx context.Context, q *goqite.Queue, name string, m []byte) error {
-	var buf bytes.Buffer
-	if err := gob.NewEncoder(&buf).Encode(message{Name: name, Message: m}); err != nil {
+func Create(ctx context.Context, q *goqite.Queue, name string, m []byte, opts ...CreateOption) error {
+	o := createOptions{codec: GobCodec{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	body, err := o.codec.Encode(Envelope{Name: name, Payload: m, Attempt: o.attempt})
+	if err != nil {
 		return err
 	}
-	return q.Send(ctx, goqite.Message{Body: buf.Bytes()})
+	return q.Send(ctx, goqite.Message{Body: body, Delay: o.delay})
 }
 
 // logger matches the info level method from the slog.Logger.
@@ -0,0 +1,242 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/maragudk/goqite"
+)
+
+// MissedRunPolicy controls what happens to the runs a [Scheduler] missed while the process
+// wasn't running (e.g. during a deploy).
+type MissedRunPolicy int
+
+const (
+	// Skip jumps straight to the next future occurrence, dropping any runs that were missed.
+	Skip MissedRunPolicy = iota
+	// Coalesce enqueues a single run for everything that was missed, then resumes the normal
+	// schedule.
+	Coalesce
+)
+
+// NewSchedulerOpts are options for [NewScheduler].
+type NewSchedulerOpts struct {
+	DB    *sql.DB
+	Queue *goqite.Queue
+	Log   logger
+
+	// Table is the name of the table used to persist schedules. Default "goqite_schedules".
+	Table string
+
+	// LeaseDuration is how long a runner replica holds the lease on a schedule row before another
+	// replica is allowed to take over. Default 30s.
+	LeaseDuration time.Duration
+}
+
+// Scheduler enqueues jobs on a recurring or one-shot basis, persisting schedules in a SQLite
+// table so they survive process restarts. With multiple Scheduler replicas pointed at the same
+// table, a lease on each schedule row ensures only one replica enqueues a given tick.
+type Scheduler struct {
+	db            *sql.DB
+	queue         *goqite.Queue
+	log           logger
+	table         string
+	leaseDuration time.Duration
+	owner         string
+}
+
+func NewScheduler(opts NewSchedulerOpts) (*Scheduler, error) {
+	if opts.Log == nil {
+		opts.Log = &discardLogger{}
+	}
+	if opts.Table == "" {
+		opts.Table = "goqite_schedules"
+	}
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = 30 * time.Second
+	}
+
+	s := &Scheduler{
+		db:            opts.DB,
+		queue:         opts.Queue,
+		log:           opts.Log,
+		table:         opts.Table,
+		leaseDuration: opts.LeaseDuration,
+		owner:         fmt.Sprintf("%v-%v", time.Now().UnixNano(), opts.Table),
+	}
+
+	_, err := s.db.Exec(fmt.Sprintf(`
+		create table if not exists %v (
+			name text primary key,
+			spec text not null,
+			payload blob not null,
+			recurring boolean not null,
+			missed_run_policy integer not null,
+			next_run timestamp not null,
+			lease_owner text not null default '',
+			lease_expires_at timestamp not null default 0
+		)
+	`, s.table))
+	if err != nil {
+		return nil, fmt.Errorf("error creating schedules table: %w", err)
+	}
+	return s, nil
+}
+
+// Schedule registers a recurring job. spec is either a [time.Duration] string or a 5-field cron
+// expression, see [parseSpec]. If name is already scheduled, its spec and payload are updated but
+// its next run time is left untouched.
+func (s *Scheduler) Schedule(ctx context.Context, name, spec string, payload []byte, policy MissedRunPolicy) error {
+	sch, err := parseSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, fmt.Sprintf(`
+		insert into %v (name, spec, payload, recurring, missed_run_policy, next_run)
+		values (@name, @spec, @payload, true, @policy, @nextRun)
+		on conflict (name) do update set spec = @spec, payload = @payload, missed_run_policy = @policy
+	`, s.table),
+		sql.Named("name", name),
+		sql.Named("spec", spec),
+		sql.Named("payload", payload),
+		sql.Named("policy", policy),
+		sql.Named("nextRun", sch.next(time.Now())),
+	)
+	return err
+}
+
+// ScheduleAt registers a one-shot job to run at t. Once it's run, the row is deleted.
+func (s *Scheduler) ScheduleAt(ctx context.Context, name string, t time.Time, payload []byte) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		insert into %v (name, spec, payload, recurring, missed_run_policy, next_run)
+		values (@name, '', @payload, false, @policy, @nextRun)
+		on conflict (name) do update set payload = @payload, next_run = @nextRun
+	`, s.table),
+		sql.Named("name", name),
+		sql.Named("payload", payload),
+		sql.Named("policy", Skip),
+		sql.Named("nextRun", t),
+	)
+	return err
+}
+
+// Start the Scheduler, ticking every interval until ctx is cancelled, enqueueing any schedule
+// whose next run has come due.
+func (s *Scheduler) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.tick(ctx); err != nil {
+				s.log.Info("Error ticking scheduler", "error", err)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) error {
+	now := time.Now()
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		`select name, spec, payload, recurring, missed_run_policy, next_run from %v where next_run <= @now and lease_expires_at < @now`, s.table),
+		sql.Named("now", now))
+	if err != nil {
+		return fmt.Errorf("error querying due schedules: %w", err)
+	}
+	defer rows.Close()
+
+	type due struct {
+		name      string
+		spec      string
+		payload   []byte
+		recurring bool
+		policy    MissedRunPolicy
+		nextRun   time.Time
+	}
+	var dues []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.name, &d.spec, &d.payload, &d.recurring, &d.policy, &d.nextRun); err != nil {
+			return fmt.Errorf("error scanning due schedule: %w", err)
+		}
+		dues = append(dues, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range dues {
+		if !s.acquireLease(ctx, d.name, now) {
+			continue
+		}
+
+		var sch schedule
+		run := true
+		if d.recurring {
+			sch, err = parseSpec(d.spec)
+			if err != nil {
+				s.log.Info("Error parsing schedule spec", "name", d.name, "error", err)
+				continue
+			}
+			run = shouldRunDue(sch, d.policy, d.nextRun, now)
+		}
+
+		if run {
+			if err := Create(ctx, s.queue, d.name, d.payload); err != nil {
+				s.log.Info("Error enqueueing scheduled job", "name", d.name, "error", err)
+				continue
+			}
+		}
+
+		if !d.recurring {
+			if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`delete from %v where name = @name`, s.table), sql.Named("name", d.name)); err != nil {
+				s.log.Info("Error deleting one-shot schedule", "name", d.name, "error", err)
+			}
+			continue
+		}
+
+		nextRun := sch.next(now)
+
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`update %v set next_run = @nextRun where name = @name`, s.table),
+			sql.Named("nextRun", nextRun), sql.Named("name", d.name)); err != nil {
+			s.log.Info("Error updating next run", "name", d.name, "error", err)
+		}
+	}
+	return nil
+}
+
+// shouldRunDue reports whether a recurring schedule, due since lastDue, should actually enqueue a
+// run at now under policy. If sch's next occurrence after lastDue is already due too, more than
+// one tick was missed (e.g. the process was down across several intervals): Coalesce still runs
+// once for the whole backlog, but Skip drops it and waits for the next future occurrence instead.
+// An on-time tick (nothing missed) always runs, regardless of policy.
+func shouldRunDue(sch schedule, policy MissedRunPolicy, lastDue, now time.Time) bool {
+	behind := !sch.next(lastDue).After(now)
+	return policy != Skip || !behind
+}
+
+// acquireLease is a leader-election-ish singleton guard: it atomically claims name's row for this
+// Scheduler instance, so that with multiple replicas polling the same table, only one of them
+// enqueues a given due schedule.
+func (s *Scheduler) acquireLease(ctx context.Context, name string, now time.Time) bool {
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		`update %v set lease_owner = @owner, lease_expires_at = @expires where name = @name and lease_expires_at < @now`, s.table),
+		sql.Named("owner", s.owner),
+		sql.Named("expires", now.Add(s.leaseDuration)),
+		sql.Named("name", name),
+		sql.Named("now", now),
+	)
+	if err != nil {
+		s.log.Info("Error acquiring schedule lease", "name", name, "error", err)
+		return false
+	}
+	n, err := res.RowsAffected()
+	return err == nil && n == 1
+}
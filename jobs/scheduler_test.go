@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestShouldRunDue(t *testing.T) {
+	sch, err := parseSpec("1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lastDue := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("on-time tick always runs", func(t *testing.T) {
+		now := lastDue
+		if !shouldRunDue(sch, Skip, lastDue, now) {
+			t.Error("got false, want true")
+		}
+		if !shouldRunDue(sch, Coalesce, lastDue, now) {
+			t.Error("got false, want true")
+		}
+	})
+
+	t.Run("Coalesce runs once for a missed backlog", func(t *testing.T) {
+		now := lastDue.Add(5 * time.Minute)
+		if !shouldRunDue(sch, Coalesce, lastDue, now) {
+			t.Error("got false, want true")
+		}
+	})
+
+	t.Run("Skip drops a missed backlog", func(t *testing.T) {
+		now := lastDue.Add(5 * time.Minute)
+		if shouldRunDue(sch, Skip, lastDue, now) {
+			t.Error("got true, want false")
+		}
+	})
+}
+
+// forceDue backdates name's next_run in table so the next tick picks it up as overdue, without
+// waiting out its real interval.
+func forceDue(t *testing.T, ctx context.Context, db *sql.DB, table, name string) {
+	t.Helper()
+	if _, err := db.ExecContext(ctx, `update `+table+` set next_run = @now where name = @name`,
+		sql.Named("now", time.Now().Add(-time.Second)), sql.Named("name", name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScheduler_tick(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a due recurring schedule enqueues a message", func(t *testing.T) {
+		db := newTestDB(t)
+		queue := newTestQueue(t, db, "jobs")
+		s, err := NewScheduler(NewSchedulerOpts{DB: db, Queue: queue, Table: "schedules_due"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Schedule(ctx, "heartbeat", "1h", []byte("ping"), Skip); err != nil {
+			t.Fatal(err)
+		}
+		forceDue(t, ctx, db, "schedules_due", "heartbeat")
+
+		if err := s.tick(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := queue.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == nil {
+			t.Fatal("expected the due schedule to enqueue a message")
+		}
+	})
+
+	t.Run("a held lease blocks a second acquirer", func(t *testing.T) {
+		db := newTestDB(t)
+		queue := newTestQueue(t, db, "jobs")
+		a, err := NewScheduler(NewSchedulerOpts{DB: db, Queue: queue, Table: "schedules_lease"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := NewScheduler(NewSchedulerOpts{DB: db, Queue: queue, Table: "schedules_lease"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := a.Schedule(ctx, "heartbeat", "1h", []byte("ping"), Skip); err != nil {
+			t.Fatal(err)
+		}
+		forceDue(t, ctx, db, "schedules_lease", "heartbeat")
+
+		now := time.Now()
+		if !a.acquireLease(ctx, "heartbeat", now) {
+			t.Fatal("expected the first acquirer to get the lease")
+		}
+		if b.acquireLease(ctx, "heartbeat", now) {
+			t.Error("expected the second acquirer to be blocked by the still-held lease")
+		}
+	})
+
+	t.Run("a one-shot schedule is deleted once it's run", func(t *testing.T) {
+		db := newTestDB(t)
+		queue := newTestQueue(t, db, "jobs")
+		s, err := NewScheduler(NewSchedulerOpts{DB: db, Queue: queue, Table: "schedules_oneshot"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.ScheduleAt(ctx, "welcome-email", time.Now().Add(-time.Second), []byte("payload")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.tick(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := queue.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m == nil {
+			t.Fatal("expected the one-shot schedule to enqueue a message")
+		}
+
+		var count int
+		row := db.QueryRowContext(ctx, `select count(*) from schedules_oneshot where name = @name`, sql.Named("name", "welcome-email"))
+		if err := row.Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("expected the one-shot schedule row to be deleted after running, got %v rows", count)
+		}
+	})
+}